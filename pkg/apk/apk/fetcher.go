@@ -0,0 +1,196 @@
+package apk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// defaultMirrorTimeout bounds how long a single mirror gets to answer before
+// Fetcher gives up on it and tries the next one. A caller-supplied ctx
+// deadline still applies on top of this per-mirror budget.
+const defaultMirrorTimeout = 30 * time.Second
+
+// RoundTripper lets callers wire in their own authentication (HTTP basic
+// auth, bearer tokens, OIDC, ECR-style keychains, etc.) without Fetcher
+// needing to know about any particular identity provider.
+type RoundTripper func(*http.Request) (*http.Response, error)
+
+// Fetcher retrieves a repository's APKINDEX over HTTP(S), failing over
+// across mirrors and caching the result on disk for offline rebuilds.
+type Fetcher struct {
+	// Mirrors is the list of base repository URIs to try, in order. The
+	// first to answer 200 OK (or 304 Not Modified with a cached copy) wins;
+	// any other status, a transport error, or a timeout moves on to the next.
+	Mirrors []string
+
+	// Transport, if set, is used to perform each request in place of
+	// http.DefaultClient.Do. Use it to attach basic auth, bearer tokens, or
+	// any other credential scheme.
+	Transport RoundTripper
+
+	// CacheDir, if set, is where fetched APKINDEX.tar.gz blobs are cached,
+	// one per URL, and revalidated with their ETag/Last-Modified so
+	// unchanged mirrors are not re-downloaded.
+	CacheDir string
+
+	// MirrorTimeout bounds how long a single mirror gets to answer before
+	// Fetcher moves on to the next one. Defaults to defaultMirrorTimeout
+	// when zero, so a hung mirror can't stall the whole fetch.
+	MirrorTimeout time.Duration
+}
+
+// NewFetcher returns a Fetcher that tries uri first, then mirrors in order.
+func NewFetcher(uri string, mirrors []string, rt RoundTripper, cacheDir string) *Fetcher {
+	return &Fetcher{
+		Mirrors:       append([]string{uri}, mirrors...),
+		Transport:     rt,
+		CacheDir:      cacheDir,
+		MirrorTimeout: defaultMirrorTimeout,
+	}
+}
+
+// FetchIndex retrieves the raw APKINDEX.tar.gz contents, trying each mirror
+// in order and failing over on any non-200 response (other than a 304 served
+// from cache), a transport error, or a timeout. If every mirror fails, the
+// first cached copy from an earlier fetch is returned, when one exists.
+func (f *Fetcher) FetchIndex(ctx context.Context) ([]byte, error) {
+	var lastErr error
+
+	for _, mirror := range f.Mirrors {
+		raw, err := f.fetchOne(ctx, fmt.Sprintf("%s/APKINDEX.tar.gz", mirror))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		return raw, nil
+	}
+
+	for _, mirror := range f.Mirrors {
+		if cached, _, ok := f.readCache(f.cachePath(fmt.Sprintf("%s/APKINDEX.tar.gz", mirror))); ok {
+			return cached, nil
+		}
+	}
+
+	return nil, fmt.Errorf("fetching APKINDEX from all mirrors: %w", lastErr)
+}
+
+func (f *Fetcher) fetchOne(ctx context.Context, uri string) ([]byte, error) {
+	timeout := f.MirrorTimeout
+	if timeout == 0 {
+		timeout = defaultMirrorTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cachePath := f.cachePath(uri)
+	cached, meta, haveCache := f.readCache(cachePath)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building request for %s: %w", uri, err)
+	}
+
+	if haveCache {
+		if meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+
+	resp, err := f.roundTrip(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", uri, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		if !haveCache {
+			return nil, fmt.Errorf("%s reported not-modified but no cache entry exists", uri)
+		}
+		return cached, nil
+
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%s returned %s", uri, resp.Status)
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response from %s: %w", uri, err)
+	}
+
+	if err := f.writeCache(cachePath, raw, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")); err != nil {
+		return nil, fmt.Errorf("caching %s: %w", uri, err)
+	}
+
+	return raw, nil
+}
+
+func (f *Fetcher) roundTrip(req *http.Request) (*http.Response, error) {
+	if f.Transport != nil {
+		return f.Transport(req)
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// cacheMeta is the sidecar file recording the validators a cached
+// APKINDEX.tar.gz was last revalidated against.
+type cacheMeta struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+func (f *Fetcher) cachePath(uri string) string {
+	sum := sha256.Sum256([]byte(uri))
+	return filepath.Join(f.CacheDir, hex.EncodeToString(sum[:])+".tar.gz")
+}
+
+func (f *Fetcher) readCache(cachePath string) (raw []byte, meta cacheMeta, ok bool) {
+	if f.CacheDir == "" {
+		return nil, cacheMeta{}, false
+	}
+
+	raw, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil, cacheMeta{}, false
+	}
+
+	if mraw, err := os.ReadFile(cachePath + ".meta"); err == nil {
+		_ = json.Unmarshal(mraw, &meta)
+	}
+
+	return raw, meta, true
+}
+
+func (f *Fetcher) writeCache(cachePath string, raw []byte, etag, lastModified string) error {
+	if f.CacheDir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(f.CacheDir, 0o755); err != nil {
+		return fmt.Errorf("creating cache dir: %w", err)
+	}
+
+	if err := os.WriteFile(cachePath, raw, 0o644); err != nil {
+		return err
+	}
+
+	mraw, err := json.Marshal(cacheMeta{ETag: etag, LastModified: lastModified})
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(cachePath+".meta", mraw, 0o644)
+}
@@ -0,0 +1,76 @@
+package apk
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetcherFailsOverToNextMirror(t *testing.T) {
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer bad.Close()
+
+	var goodHits int
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		goodHits++
+		w.Write([]byte("index contents"))
+	}))
+	defer good.Close()
+
+	f := NewFetcher(bad.URL, []string{good.URL}, nil, "")
+
+	raw, err := f.FetchIndex(context.Background())
+	if err != nil {
+		t.Fatalf("FetchIndex: %v", err)
+	}
+
+	if string(raw) != "index contents" {
+		t.Fatalf("FetchIndex returned %q, want %q", raw, "index contents")
+	}
+
+	if goodHits != 1 {
+		t.Fatalf("good mirror was hit %d times, want 1", goodHits)
+	}
+}
+
+func TestFetcherReusesCacheOnNotModified(t *testing.T) {
+	const etag = `"abc123"`
+
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		w.Write([]byte("index contents"))
+	}))
+	defer srv.Close()
+
+	cacheDir := t.TempDir()
+	f := NewFetcher(srv.URL, nil, nil, cacheDir)
+
+	first, err := f.FetchIndex(context.Background())
+	if err != nil {
+		t.Fatalf("first FetchIndex: %v", err)
+	}
+
+	second, err := f.FetchIndex(context.Background())
+	if err != nil {
+		t.Fatalf("second FetchIndex: %v", err)
+	}
+
+	if string(first) != string(second) {
+		t.Fatalf("cached fetch returned %q, want %q", second, first)
+	}
+
+	if hits != 2 {
+		t.Fatalf("server was hit %d times, want 2 (one per FetchIndex call)", hits)
+	}
+}
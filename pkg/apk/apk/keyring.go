@@ -0,0 +1,117 @@
+package apk
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // required for compatibility with older apk-tools signatures
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DefaultKeyDir is the location apk-tools itself uses for trusted signing
+// keys.
+const DefaultKeyDir = "/etc/apk/keys"
+
+// ErrUntrustedRepository is returned when an APKINDEX's signature cannot be
+// verified against the configured KeyRing. Callers that need to fail closed
+// on unsigned or mis-signed mirrors should check for it with errors.Is.
+var ErrUntrustedRepository = errors.New("untrusted repository")
+
+// KeyRing holds the set of trusted APK signing keys used to verify APKINDEX
+// signatures, keyed by the filename apk-tools uses to identify them (e.g.
+// "alpine-devel@lists.alpinelinux.org-5243ef4b.rsa.pub").
+type KeyRing struct {
+	keys map[string]*rsa.PublicKey
+}
+
+// NewKeyRing returns an empty KeyRing.
+func NewKeyRing() *KeyRing {
+	return &KeyRing{keys: map[string]*rsa.PublicKey{}}
+}
+
+// NewDefaultKeyRing returns a KeyRing preloaded with every key under
+// DefaultKeyDir. A missing directory is not an error, since chroots that
+// have not had any keys installed yet are a normal state.
+func NewDefaultKeyRing() (*KeyRing, error) {
+	kr := NewKeyRing()
+	if err := kr.LoadDir(DefaultKeyDir); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return kr, nil
+}
+
+// LoadDir adds every ".pub" key found directly under dir, mirroring the
+// layout apk-tools expects under /etc/apk/keys.
+func (k *KeyRing) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pub" {
+			continue
+		}
+		if err := k.LoadFile(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadFile adds a single PEM-encoded RSA public key to the keyring, keyed by
+// its base filename.
+func (k *KeyRing) LoadFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading key %s: %w", path, err)
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return fmt.Errorf("no PEM data found in key %s", path)
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("parsing public key %s: %w", path, err)
+	}
+
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("key %s is not an RSA public key", path)
+	}
+
+	k.keys[filepath.Base(path)] = rsaPub
+	return nil
+}
+
+// Verify checks sig (the raw contents of a .SIGN.RSA.<keyname> tar member)
+// against data (the raw APKINDEX member) using the named key. It tries
+// SHA-256 first and falls back to SHA-1, since older Alpine signatures are
+// still SHA-1-based.
+func (k *KeyRing) Verify(keyname string, data, sig []byte) error {
+	pub, ok := k.keys[keyname]
+	if !ok {
+		return fmt.Errorf("%w: unknown signing key %q", ErrUntrustedRepository, keyname)
+	}
+
+	sum256 := sha256.Sum256(data)
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, sum256[:], sig); err == nil {
+		return nil
+	}
+
+	sum1 := sha1.Sum(data) //nolint:gosec // see crypto/sha1 import above
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA1, sum1[:], sig); err == nil {
+		return nil
+	}
+
+	return fmt.Errorf("%w: signature from %q did not verify", ErrUntrustedRepository, keyname)
+}
@@ -0,0 +1,87 @@
+package apk
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestKey(t *testing.T, dir, name string, pub *rsa.PublicKey) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("marshaling public key: %v", err)
+	}
+
+	raw := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatalf("writing key %s: %v", path, err)
+	}
+
+	return path
+}
+
+func TestKeyRingVerify(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating other key: %v", err)
+	}
+
+	dir := t.TempDir()
+	writeTestKey(t, dir, "test@apko-5243ef4b.rsa.pub", &priv.PublicKey)
+
+	kr := NewKeyRing()
+	if err := kr.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir: %v", err)
+	}
+
+	data := []byte("APKINDEX contents")
+	sum := sha256.Sum256(data)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatalf("signing: %v", err)
+	}
+
+	if err := kr.Verify("test@apko-5243ef4b.rsa.pub", data, sig); err != nil {
+		t.Fatalf("Verify() with a valid signature returned error: %v", err)
+	}
+
+	t.Run("tampered data", func(t *testing.T) {
+		if err := kr.Verify("test@apko-5243ef4b.rsa.pub", []byte("tampered"), sig); !errors.Is(err, ErrUntrustedRepository) {
+			t.Fatalf("Verify() with tampered data = %v, want ErrUntrustedRepository", err)
+		}
+	})
+
+	t.Run("wrong key signed it", func(t *testing.T) {
+		otherSum := sha256.Sum256(data)
+		otherSig, err := rsa.SignPKCS1v15(rand.Reader, other, crypto.SHA256, otherSum[:])
+		if err != nil {
+			t.Fatalf("signing with other key: %v", err)
+		}
+
+		if err := kr.Verify("test@apko-5243ef4b.rsa.pub", data, otherSig); !errors.Is(err, ErrUntrustedRepository) {
+			t.Fatalf("Verify() with a signature from an untrusted key = %v, want ErrUntrustedRepository", err)
+		}
+	})
+
+	t.Run("unknown key name", func(t *testing.T) {
+		if err := kr.Verify("nope@apko-00000000.rsa.pub", data, sig); !errors.Is(err, ErrUntrustedRepository) {
+			t.Fatalf("Verify() with an unknown key = %v, want ErrUntrustedRepository", err)
+		}
+	})
+}
@@ -7,6 +7,17 @@ import (
 
 type Repository struct {
 	URI string
+
+	keyring *KeyRing
+}
+
+// WithKeyring configures r to verify its APKINDEX signature against kr
+// before trusting it, and returns r for chaining. Callers that don't need
+// signature verification (or are fine trusting an unsigned mirror) can skip
+// this entirely; a Repository with no keyring is never marked Verified.
+func (r *Repository) WithKeyring(kr *KeyRing) *Repository {
+	r.keyring = kr
+	return r
 }
 
 // NewRepositoryFromComponents creates a new Repository with the uri constructed
@@ -39,7 +50,34 @@ func (r *Repository) IsRemote() bool {
 // RepositoryWithIndex represents a repository with the index read and parsed
 type RepositoryWithIndex struct {
 	*Repository
-	index *APKIndex
+	index    *APKIndex
+	verified bool
+}
+
+// VerifySignature checks a detached APKINDEX signature (sigName is the
+// ".SIGN.RSA.<keyname>" tar member name, sigBytes its contents) against
+// indexBytes (the raw "APKINDEX" tar member) using r.keyring, recording the
+// result so later calls to Verified() reflect it. It returns
+// ErrUntrustedRepository if no keyring is configured or the signature does
+// not verify against it.
+func (r *RepositoryWithIndex) VerifySignature(sigName string, indexBytes, sigBytes []byte) error {
+	if r.keyring == nil {
+		return fmt.Errorf("%w: no keyring configured for %s", ErrUntrustedRepository, r.URI)
+	}
+
+	keyname := strings.TrimPrefix(sigName, ".SIGN.RSA.")
+	if err := r.keyring.Verify(keyname, indexBytes, sigBytes); err != nil {
+		return err
+	}
+
+	r.verified = true
+	return nil
+}
+
+// Verified reports whether this repository's APKINDEX signature has been
+// checked against its keyring and found valid.
+func (r *RepositoryWithIndex) Verified() bool {
+	return r.verified
 }
 
 // Packages returns a list of RepositoryPackage in this repository
@@ -16,24 +16,27 @@ package build
 
 import (
 	"fmt"
-	"os"
 	"runtime"
 	"strings"
 
 	"chainguard.dev/apko/pkg/build/types"
 	"chainguard.dev/apko/pkg/exec"
-	apkofs "chainguard.dev/apko/pkg/fs"
 	"chainguard.dev/apko/pkg/s6"
 	"chainguard.dev/apko/pkg/sbom"
-	"chainguard.dev/apko/pkg/tarball"
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	v1tar "github.com/google/go-containerregistry/pkg/v1/tarball"
 )
 
 type BuildImplementation interface {
 	Refresh(*Options) (*s6.Context, *exec.Executor, error)
 	BuildTarball(o *Options) (string, error)
+	PushImage(o *Options) error
 	GenerateSBOM(o *Options) error
+	BuildIndex(o *Options, archOutputs map[types.Architecture]string) (v1.ImageIndex, error)
+	GenerateIndexSBOM(o *Options, idx v1.ImageIndex, archOutputs map[types.Architecture]string) error
 }
 
 type defaultBuildImplementation struct{}
@@ -43,6 +46,15 @@ func (di *defaultBuildImplementation) Refresh(o *Options) (*s6.Context, *exec.Ex
 		o.TarballPath = ""
 	}
 
+	// Resolve o.TimestampPolicy into a concrete SourceDateEpoch now, so every
+	// later consumer (the layer tarball, the OCI layer, the image config)
+	// reads the same value from Options instead of each picking its own.
+	ts, err := o.TimestampPolicy.Resolve(o.SourceDateEpoch)
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolving timestamp policy: %w", err)
+	}
+	o.SourceDateEpoch = ts
+
 	hostArch := types.ParseArchitecture(runtime.GOARCH)
 
 	execOpts := []exec.Option{exec.WithProot(o.UseProot)}
@@ -59,32 +71,56 @@ func (di *defaultBuildImplementation) Refresh(o *Options) (*s6.Context, *exec.Ex
 	return s6.New(o.WorkDir, o.Log), executor, nil
 }
 
+// BuildTarball packages the build context (o.WorkDir) via the Packager
+// selected by o.PackagerType, defaulting to a plain gzip-compressed tarball.
 func (di *defaultBuildImplementation) BuildTarball(o *Options) (string, error) {
-	var outfile *os.File
-	var err error
+	p, err := lookupPackager(o.PackagerType)
+	if err != nil {
+		return "", err
+	}
 
-	if o.TarballPath != "" {
-		outfile, err = os.Create(o.TarballPath)
-	} else {
-		outfile, err = os.CreateTemp("", "apko-*.tar.gz")
+	return p.Package(o)
+}
+
+// PushImage wraps the tarball produced by BuildTarball as a single-layer OCI
+// image and writes it to every ref in o.Tags, so pipelines can go straight
+// from an apko config to a pushed manifest without shelling out to crane or
+// docker load. Each call pushes one single-architecture image; BuildIndex
+// assembles per-arch tarballs into a multi-arch index.
+func (di *defaultBuildImplementation) PushImage(o *Options) error {
+	if len(o.Tags) == 0 {
+		return fmt.Errorf("no tags configured to push to")
 	}
+
+	layer, err := v1tar.LayerFromFile(o.TarballPath)
 	if err != nil {
-		return "", fmt.Errorf("opening the build context tarball path failed: %w", err)
+		return fmt.Errorf("failed to create OCI layer from tar.gz: %w", err)
 	}
-	o.TarballPath = outfile.Name()
-	defer outfile.Close()
 
-	tw, err := tarball.NewContext(tarball.WithSourceDateEpoch(o.SourceDateEpoch))
+	img, err := finalizeLayerImage(o, o.Arch, layer)
 	if err != nil {
-		return "", fmt.Errorf("failed to construct tarball build context: %w", err)
+		return err
 	}
 
-	if err := tw.WriteArchive(outfile, apkofs.DirFS(o.WorkDir)); err != nil {
-		return "", fmt.Errorf("failed to generate tarball for image: %w", err)
+	keychain, err := resolveKeychain(o)
+	if err != nil {
+		return fmt.Errorf("resolving registry credentials: %w", err)
+	}
+
+	for _, tagStr := range o.Tags {
+		tag, err := name.NewTag(tagStr)
+		if err != nil {
+			return fmt.Errorf("parsing tag %s: %w", tagStr, err)
+		}
+
+		if err := remote.Write(tag, img, remote.WithAuthFromKeychain(keychain)); err != nil {
+			return fmt.Errorf("pushing image %s: %w", tag, err)
+		}
+
+		o.Log.Printf("pushed image as %s", tag)
 	}
 
-	o.Log.Printf("built image layer tarball as %s", outfile.Name())
-	return outfile.Name(), nil
+	return nil
 }
 
 // GenerateSBOM runs the sbom generation
@@ -108,7 +144,25 @@ func (di *defaultBuildImplementation) GenerateSBOM(o *Options) error {
 		return fmt.Errorf("could not calculate layer digest: %w", err)
 	}
 
-	// Parse the image reference
+	s.Options.ImageInfo.Arch = o.Arch
+	if err := populateSBOMImageInfo(s, o, digest.String()); err != nil {
+		return err
+	}
+
+	if _, err := s.Generate(); err != nil {
+		return fmt.Errorf("generating SBOMs: %w", err)
+	}
+
+	return nil
+}
+
+// populateSBOMImageInfo fills in s's ImageInfo.Tag/Name (when o.Tags is
+// set), Packages, OutputDir, and Formats from o, and stamps ImageInfo.Digest
+// with digest. It is shared by GenerateSBOM (single arch) and
+// GenerateIndexSBOM (the index itself) so their metadata population can't
+// drift; callers that need ImageInfo.Arch set it themselves first, since
+// that only makes sense for a single-arch SBOM.
+func populateSBOMImageInfo(s *sbom.SBOM, o *Options, digest string) error {
 	if len(o.Tags) > 0 {
 		tag, err := name.NewTag(o.Tags[0])
 		if err != nil {
@@ -124,15 +178,11 @@ func (di *defaultBuildImplementation) GenerateSBOM(o *Options) error {
 	if err != nil {
 		return fmt.Errorf("getting installed packages from sbom: %w", err)
 	}
-	s.Options.ImageInfo.Arch = o.Arch
-	s.Options.ImageInfo.Digest = digest.String()
+
+	s.Options.ImageInfo.Digest = digest
 	s.Options.OutputDir = o.SBOMPath
 	s.Options.Packages = packages
 	s.Options.Formats = o.SBOMFormats
 
-	if _, err := s.Generate(); err != nil {
-		return fmt.Errorf("generating SBOMs: %w", err)
-	}
-
 	return nil
 }
@@ -0,0 +1,104 @@
+package build
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"chainguard.dev/apko/pkg/build/types"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// writeTestLayerTarball writes a minimal gzip-compressed tarball containing
+// a single file, standing in for the tarball BuildTarball would normally
+// have produced.
+func writeTestLayerTarball(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "layer.tar.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("creating tarball: %v", err)
+	}
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+
+	contents := []byte("hello from a test layer\n")
+	if err := tw.WriteHeader(&tar.Header{
+		Name: "hello.txt",
+		Mode: 0o644,
+		Size: int64(len(contents)),
+	}); err != nil {
+		t.Fatalf("writing tar header: %v", err)
+	}
+	if _, err := tw.Write(contents); err != nil {
+		t.Fatalf("writing tar contents: %v", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatalf("closing tar writer: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("closing gzip writer: %v", err)
+	}
+
+	return path
+}
+
+func TestPushImageStampsConfig(t *testing.T) {
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+
+	tag, err := name.NewTag(strings.TrimPrefix(srv.URL, "http://") + "/test/image:latest")
+	if err != nil {
+		t.Fatalf("parsing tag: %v", err)
+	}
+
+	o := &Options{
+		Arch:            types.ParseArchitecture("amd64"),
+		TarballPath:     writeTestLayerTarball(t),
+		SourceDateEpoch: time.Unix(12345, 0),
+		Tags:            []string{tag.String()},
+		Log:             log.New(os.Stderr, "", 0),
+	}
+
+	di := &defaultBuildImplementation{}
+	if err := di.PushImage(o); err != nil {
+		t.Fatalf("PushImage: %v", err)
+	}
+
+	desc, err := remote.Get(tag)
+	if err != nil {
+		t.Fatalf("fetching pushed image: %v", err)
+	}
+
+	img, err := desc.Image()
+	if err != nil {
+		t.Fatalf("reading pushed image: %v", err)
+	}
+
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		t.Fatalf("reading pushed image config: %v", err)
+	}
+
+	if cfg.OS != "linux" {
+		t.Errorf("pushed image config.OS = %q, want %q", cfg.OS, "linux")
+	}
+	if want := o.Arch.ToOCIPlatform(); cfg.Architecture != want {
+		t.Errorf("pushed image config.Architecture = %q, want %q", cfg.Architecture, want)
+	}
+	if !cfg.Created.Time.Equal(o.SourceDateEpoch) {
+		t.Errorf("pushed image config.Created = %v, want %v", cfg.Created.Time, o.SourceDateEpoch)
+	}
+}
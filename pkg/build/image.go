@@ -0,0 +1,59 @@
+// Copyright 2022 Chainguard, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"fmt"
+
+	"chainguard.dev/apko/pkg/build/types"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+)
+
+// finalizeLayerImage wraps layer as the sole layer of a new image, stamps
+// its config's OS/Architecture to match arch, and stamps its timestamps
+// (layer history and config Created) to o.SourceDateEpoch -- already
+// resolved from o.TimestampPolicy by Refresh. PushImage, the OCI-layout
+// packager, and BuildIndex all build images this way, so a single place
+// owns what "reproducible" and "correctly labeled" mean for an apko-built
+// image.
+func finalizeLayerImage(o *Options, arch types.Architecture, layer v1.Layer) (v1.Image, error) {
+	img, err := mutate.Append(empty.Image, mutate.Addendum{Layer: layer})
+	if err != nil {
+		return nil, fmt.Errorf("failed to append layer to image: %w", err)
+	}
+
+	img, err = mutate.Time(img, o.SourceDateEpoch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set image timestamps: %w", err)
+	}
+
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("getting image config: %w", err)
+	}
+
+	cfg = cfg.DeepCopy()
+	cfg.OS = "linux"
+	cfg.Architecture = arch.ToOCIPlatform()
+
+	img, err = mutate.ConfigFile(img, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("setting image config: %w", err)
+	}
+
+	return img, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 Chainguard, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/docker/cli/cli/config"
+	dockertypes "github.com/docker/cli/cli/config/types"
+	"github.com/google/go-containerregistry/pkg/authn"
+)
+
+// dockerConfigKeychain resolves credentials from a single docker config.json
+// file at a caller-chosen path, independent of the process-wide DOCKER_CONFIG
+// environment variable or $HOME/.docker/config.json that authn.DefaultKeychain
+// reads. Each Resolve call opens the file fresh, so concurrent callers (e.g.
+// one PushImage per arch) never race on shared process state.
+type dockerConfigKeychain struct {
+	path string
+}
+
+func (k *dockerConfigKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	f, err := os.Open(k.path)
+	if err != nil {
+		return nil, fmt.Errorf("opening docker config %s: %w", k.path, err)
+	}
+	defer f.Close()
+
+	cf, err := config.LoadFromReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing docker config %s: %w", k.path, err)
+	}
+
+	cfg, err := cf.GetAuthConfig(target.RegistryStr())
+	if err != nil {
+		return nil, fmt.Errorf("getting auth config for %s from %s: %w", target.RegistryStr(), k.path, err)
+	}
+
+	if cfg == (dockertypes.AuthConfig{}) {
+		return authn.Anonymous, nil
+	}
+
+	return authn.FromConfig(authn.AuthConfig{
+		Username:      cfg.Username,
+		Password:      cfg.Password,
+		Auth:          cfg.Auth,
+		IdentityToken: cfg.IdentityToken,
+		RegistryToken: cfg.RegistryToken,
+	}), nil
+}
+
+// resolveKeychain builds the authn.Keychain PushImage authenticates with: the
+// default keychain (docker config, podman auth, etc.), layered with
+// o.DockerConfigPath when one is configured, so callers that keep registry
+// credentials outside ~/.docker/config.json don't need to stage them there
+// first.
+func resolveKeychain(o *Options) (authn.Keychain, error) {
+	if o.DockerConfigPath == "" {
+		return authn.DefaultKeychain, nil
+	}
+
+	return authn.NewMultiKeychain(authn.DefaultKeychain, &dockerConfigKeychain{path: o.DockerConfigPath}), nil
+}
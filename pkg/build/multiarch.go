@@ -0,0 +1,113 @@
+// Copyright 2022 Chainguard, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"fmt"
+	"sort"
+
+	"chainguard.dev/apko/pkg/build/types"
+	"chainguard.dev/apko/pkg/sbom"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	v1tar "github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+// BuildIndex stitches the per-arch tarballs in archOutputs (as produced by
+// one BuildTarball call per arch) into a single v1.ImageIndex with correct
+// Platform descriptors, so a multi-arch build can be pushed as one manifest
+// list instead of requiring external `docker manifest` post-processing.
+func (di *defaultBuildImplementation) BuildIndex(o *Options, archOutputs map[types.Architecture]string) (v1.ImageIndex, error) {
+	if len(archOutputs) == 0 {
+		return nil, fmt.Errorf("no per-arch tarballs to index")
+	}
+
+	idx := empty.Index
+
+	// Map iteration order is randomized, so appending manifests in range
+	// order would make the index (and its digest) nondeterministic across
+	// otherwise-identical builds. Walk the architectures in a fixed order
+	// instead.
+	arches := make([]types.Architecture, 0, len(archOutputs))
+	for arch := range archOutputs {
+		arches = append(arches, arch)
+	}
+	sort.Slice(arches, func(i, j int) bool { return arches[i].String() < arches[j].String() })
+
+	for _, arch := range arches {
+		tarballPath := archOutputs[arch]
+
+		layer, err := v1tar.LayerFromFile(tarballPath)
+		if err != nil {
+			return nil, fmt.Errorf("creating OCI layer for %s: %w", arch, err)
+		}
+
+		img, err := finalizeLayerImage(o, arch, layer)
+		if err != nil {
+			return nil, fmt.Errorf("finalizing image for %s: %w", arch, err)
+		}
+
+		idx = mutate.AppendManifests(idx, mutate.IndexAddendum{
+			Add: img,
+			Descriptor: v1.Descriptor{
+				Platform: &v1.Platform{
+					OS:           "linux",
+					Architecture: arch.ToOCIPlatform(),
+				},
+			},
+		})
+	}
+
+	o.Log.Printf("built image index for %d architectures", len(archOutputs))
+	return idx, nil
+}
+
+// GenerateIndexSBOM generates one SBOM per architecture in archOutputs (the
+// same way GenerateSBOM does for a single-arch build), plus one additional
+// SBOM for idx itself, keyed to the manifest list's own digest rather than
+// any single arch's layer digest.
+func (di *defaultBuildImplementation) GenerateIndexSBOM(o *Options, idx v1.ImageIndex, archOutputs map[types.Architecture]string) error {
+	if len(o.SBOMFormats) == 0 {
+		o.Log.Printf("skipping SBOM generation")
+		return nil
+	}
+
+	for arch, tarballPath := range archOutputs {
+		archOpts := *o
+		archOpts.Arch = arch
+		archOpts.TarballPath = tarballPath
+
+		if err := di.GenerateSBOM(&archOpts); err != nil {
+			return fmt.Errorf("generating SBOM for %s: %w", arch, err)
+		}
+	}
+
+	digest, err := idx.Digest()
+	if err != nil {
+		return fmt.Errorf("calculating index digest: %w", err)
+	}
+
+	s := sbom.NewWithWorkDir(o.WorkDir, o.Arch)
+	if err := populateSBOMImageInfo(s, o, digest.String()); err != nil {
+		return err
+	}
+
+	if _, err := s.Generate(); err != nil {
+		return fmt.Errorf("generating index SBOM: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package build
+
+import (
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"chainguard.dev/apko/pkg/build/types"
+)
+
+func TestBuildIndexStampsPerArchConfig(t *testing.T) {
+	amd64 := types.ParseArchitecture("amd64")
+	arm64 := types.ParseArchitecture("arm64")
+
+	archOutputs := map[types.Architecture]string{
+		amd64: writeTestLayerTarball(t),
+		arm64: writeTestLayerTarball(t),
+	}
+
+	o := &Options{
+		SourceDateEpoch: time.Unix(12345, 0),
+		Log:             log.New(os.Stderr, "", 0),
+	}
+
+	di := &defaultBuildImplementation{}
+	idx, err := di.BuildIndex(o, archOutputs)
+	if err != nil {
+		t.Fatalf("BuildIndex: %v", err)
+	}
+
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		t.Fatalf("IndexManifest: %v", err)
+	}
+
+	if len(manifest.Manifests) != len(archOutputs) {
+		t.Fatalf("got %d manifests, want %d", len(manifest.Manifests), len(archOutputs))
+	}
+
+	for _, m := range manifest.Manifests {
+		if m.Platform == nil {
+			t.Fatalf("manifest for digest %s has no Platform", m.Digest)
+		}
+
+		img, err := idx.Image(m.Digest)
+		if err != nil {
+			t.Fatalf("fetching image for %s: %v", m.Platform.Architecture, err)
+		}
+
+		cfg, err := img.ConfigFile()
+		if err != nil {
+			t.Fatalf("reading config for %s: %v", m.Platform.Architecture, err)
+		}
+
+		if cfg.Architecture != m.Platform.Architecture {
+			t.Errorf("image config.Architecture = %q, descriptor Platform.Architecture = %q", cfg.Architecture, m.Platform.Architecture)
+		}
+		if cfg.OS != m.Platform.OS {
+			t.Errorf("image config.OS = %q, descriptor Platform.OS = %q", cfg.OS, m.Platform.OS)
+		}
+	}
+}
+
+func TestBuildIndexDeterministicOrdering(t *testing.T) {
+	archOutputs := map[types.Architecture]string{
+		types.ParseArchitecture("amd64"):  writeTestLayerTarball(t),
+		types.ParseArchitecture("arm64"):  writeTestLayerTarball(t),
+		types.ParseArchitecture("arm/v7"): writeTestLayerTarball(t),
+	}
+
+	o := &Options{
+		SourceDateEpoch: time.Unix(12345, 0),
+		Log:             log.New(os.Stderr, "", 0),
+	}
+
+	di := &defaultBuildImplementation{}
+
+	var digests []string
+	for i := 0; i < 3; i++ {
+		idx, err := di.BuildIndex(o, archOutputs)
+		if err != nil {
+			t.Fatalf("BuildIndex (run %d): %v", i, err)
+		}
+
+		digest, err := idx.Digest()
+		if err != nil {
+			t.Fatalf("Digest (run %d): %v", i, err)
+		}
+
+		digests = append(digests, digest.String())
+	}
+
+	for i, d := range digests[1:] {
+		if d != digests[0] {
+			t.Fatalf("run %d produced digest %s, want %s (map iteration order should not affect the result)", i+1, d, digests[0])
+		}
+	}
+}
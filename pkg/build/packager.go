@@ -0,0 +1,199 @@
+// Copyright 2022 Chainguard, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"strconv"
+
+	apkofs "chainguard.dev/apko/pkg/fs"
+	"chainguard.dev/apko/pkg/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	v1tar "github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+// PackagerType selects which Packager BuildTarball uses to turn the finished
+// build context (o.WorkDir) into its on-disk artifact.
+type PackagerType string
+
+const (
+	// PackagerTarball is the default: a single gzip-compressed tarball,
+	// suitable for loading directly as an OCI layer.
+	PackagerTarball PackagerType = "tar"
+
+	// PackagerOCILayout writes an OCI image layout directory instead of a
+	// single tarball, for tools that consume layouts directly.
+	PackagerOCILayout PackagerType = "oci-layout"
+
+	// PackagerSquashFS produces a squashfs image of the root filesystem, for
+	// consumers that boot it directly (kernel/initramfs, k3s, immutable OS
+	// images) rather than layering it as a Docker-style tarball.
+	PackagerSquashFS PackagerType = "squashfs"
+)
+
+// Packager assembles the finished build context into whatever on-disk
+// artifact its PackagerType produces, returning the path to it.
+type Packager interface {
+	Package(o *Options) (string, error)
+}
+
+var packagers = map[PackagerType]Packager{
+	PackagerTarball:   tarPackager{},
+	PackagerOCILayout: ociLayoutPackager{},
+	PackagerSquashFS:  squashfsPackager{},
+}
+
+// RegisterPackager lets downstream projects add new PackagerTypes (or
+// override the built-in ones) without forking apko.
+func RegisterPackager(t PackagerType, p Packager) {
+	packagers[t] = p
+}
+
+// lookupPackager resolves o.PackagerType to its Packager, defaulting to
+// PackagerTarball for the zero value so existing callers are unaffected.
+func lookupPackager(t PackagerType) (Packager, error) {
+	if t == "" {
+		t = PackagerTarball
+	}
+
+	p, ok := packagers[t]
+	if !ok {
+		return nil, fmt.Errorf("unknown packager type %q", t)
+	}
+
+	return p, nil
+}
+
+// tarPackager writes the build context out as a single gzip-compressed
+// tarball. This is apko's original, and still default, output format.
+type tarPackager struct{}
+
+func (tarPackager) Package(o *Options) (string, error) {
+	var outfile *os.File
+	var err error
+
+	if o.TarballPath != "" {
+		outfile, err = os.Create(o.TarballPath)
+	} else {
+		outfile, err = os.CreateTemp("", "apko-*.tar.gz")
+	}
+	if err != nil {
+		return "", fmt.Errorf("opening the build context tarball path failed: %w", err)
+	}
+	o.TarballPath = outfile.Name()
+	defer outfile.Close()
+
+	tw, err := tarball.NewContext(tarball.WithSourceDateEpoch(o.SourceDateEpoch))
+	if err != nil {
+		return "", fmt.Errorf("failed to construct tarball build context: %w", err)
+	}
+
+	if err := tw.WriteArchive(outfile, apkofs.DirFS(o.WorkDir)); err != nil {
+		return "", fmt.Errorf("failed to generate tarball for image: %w", err)
+	}
+
+	o.Log.Printf("built image layer tarball as %s", outfile.Name())
+	return outfile.Name(), nil
+}
+
+// ociLayoutPackager writes an OCI image layout directory (a single-layer
+// image wrapping the build context) instead of a bare tarball.
+type ociLayoutPackager struct{}
+
+func (ociLayoutPackager) Package(o *Options) (string, error) {
+	dir := o.TarballPath
+	if dir == "" {
+		var err error
+		dir, err = os.MkdirTemp("", "apko-oci-*")
+		if err != nil {
+			return "", fmt.Errorf("creating oci layout dir: %w", err)
+		}
+	}
+
+	layerFile, err := os.CreateTemp("", "apko-layer-*.tar.gz")
+	if err != nil {
+		return "", fmt.Errorf("creating temporary layer tarball: %w", err)
+	}
+	defer os.Remove(layerFile.Name())
+	defer layerFile.Close()
+
+	tw, err := tarball.NewContext(tarball.WithSourceDateEpoch(o.SourceDateEpoch))
+	if err != nil {
+		return "", fmt.Errorf("failed to construct tarball build context: %w", err)
+	}
+
+	if err := tw.WriteArchive(layerFile, apkofs.DirFS(o.WorkDir)); err != nil {
+		return "", fmt.Errorf("failed to generate layer tarball: %w", err)
+	}
+
+	layer, err := v1tar.LayerFromFile(layerFile.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to create OCI layer: %w", err)
+	}
+
+	img, err := finalizeLayerImage(o, o.Arch, layer)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := layout.Write(dir, empty.Index)
+	if err != nil {
+		return "", fmt.Errorf("initializing oci layout at %s: %w", dir, err)
+	}
+
+	if err := path.AppendImage(img); err != nil {
+		return "", fmt.Errorf("appending image to oci layout at %s: %w", dir, err)
+	}
+
+	o.Log.Printf("built OCI image layout as %s", dir)
+	return dir, nil
+}
+
+// squashfsPackager produces a squashfs image of the build context by
+// shelling out to mksquashfs, for consumers that boot the root filesystem
+// directly rather than layering it.
+type squashfsPackager struct{}
+
+func (squashfsPackager) Package(o *Options) (string, error) {
+	out := o.TarballPath
+	if out == "" {
+		f, err := os.CreateTemp("", "apko-*.squashfs")
+		if err != nil {
+			return "", fmt.Errorf("creating squashfs output path: %w", err)
+		}
+		out = f.Name()
+		f.Close()
+		os.Remove(out)
+	}
+
+	// -all-time pins every entry's timestamp to o.SourceDateEpoch (already
+	// resolved from o.TimestampPolicy by Refresh), matching the
+	// reproducibility guarantee tarPackager and ociLayoutPackager give via
+	// tarball.WithSourceDateEpoch.
+	cmd := osexec.Command("mksquashfs", o.WorkDir, out, "-noappend", "-all-root",
+		"-all-time", strconv.FormatInt(o.SourceDateEpoch.Unix(), 10))
+	cmd.Stdout = o.Log.Writer()
+	cmd.Stderr = o.Log.Writer()
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running mksquashfs: %w", err)
+	}
+
+	o.Log.Printf("built squashfs image as %s", out)
+	return out, nil
+}
@@ -0,0 +1,63 @@
+package build
+
+import "testing"
+
+func TestLookupPackagerDefaultsToTar(t *testing.T) {
+	p, err := lookupPackager("")
+	if err != nil {
+		t.Fatalf("lookupPackager: %v", err)
+	}
+
+	if _, ok := p.(tarPackager); !ok {
+		t.Fatalf("lookupPackager(\"\") = %T, want tarPackager", p)
+	}
+}
+
+func TestLookupPackagerKnownTypes(t *testing.T) {
+	tests := []struct {
+		t    PackagerType
+		want Packager
+	}{
+		{PackagerTarball, tarPackager{}},
+		{PackagerOCILayout, ociLayoutPackager{}},
+		{PackagerSquashFS, squashfsPackager{}},
+	}
+
+	for _, tt := range tests {
+		p, err := lookupPackager(tt.t)
+		if err != nil {
+			t.Fatalf("lookupPackager(%q): %v", tt.t, err)
+		}
+		if p != tt.want {
+			t.Fatalf("lookupPackager(%q) = %#v, want %#v", tt.t, p, tt.want)
+		}
+	}
+}
+
+func TestLookupPackagerUnknown(t *testing.T) {
+	if _, err := lookupPackager("bogus"); err == nil {
+		t.Fatal("lookupPackager with an unregistered type returned nil error, want an error")
+	}
+}
+
+type fakePackager struct{}
+
+func (fakePackager) Package(o *Options) (string, error) {
+	return "fake", nil
+}
+
+func TestRegisterPackagerAddsType(t *testing.T) {
+	const custom PackagerType = "custom-for-test"
+
+	RegisterPackager(custom, fakePackager{})
+	defer delete(packagers, custom)
+
+	p, err := lookupPackager(custom)
+	if err != nil {
+		t.Fatalf("lookupPackager(%q): %v", custom, err)
+	}
+
+	if _, ok := p.(fakePackager); !ok {
+		t.Fatalf("lookupPackager(%q) = %T, want fakePackager", custom, p)
+	}
+}
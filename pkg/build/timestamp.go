@@ -0,0 +1,59 @@
+// Copyright 2022 Chainguard, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimestampPolicy controls how Refresh resolves o.SourceDateEpoch, which is
+// in turn the single timestamp threaded through the layer tarball, the
+// resulting OCI layer's Created field, and the image config's Created field.
+// Keeping all three in agreement is what makes a build reproducible.
+type TimestampPolicy string
+
+const (
+	// TimestampPolicyZero pins every timestamp to the UNIX epoch, per the
+	// reproducible-builds recommendation for tools with no better notion of
+	// a build time.
+	TimestampPolicyZero TimestampPolicy = "zero"
+
+	// TimestampPolicySourceDateEpoch (the default) uses o.SourceDateEpoch as
+	// already populated from the SOURCE_DATE_EPOCH environment variable or
+	// explicit configuration.
+	TimestampPolicySourceDateEpoch TimestampPolicy = "source-date-epoch"
+
+	// TimestampPolicyBuildTime stamps the build with the wall-clock time at
+	// which Refresh runs. Images built this way are not reproducible.
+	TimestampPolicyBuildTime TimestampPolicy = "build-time"
+)
+
+// Resolve returns the concrete time p maps to, given the SourceDateEpoch
+// already parsed onto Options. It returns an error for any value other than
+// the three recognized policies, rather than silently falling back to a
+// default.
+func (p TimestampPolicy) Resolve(sourceDateEpoch time.Time) (time.Time, error) {
+	switch p {
+	case "", TimestampPolicySourceDateEpoch:
+		return sourceDateEpoch, nil
+	case TimestampPolicyZero:
+		return time.Unix(0, 0), nil
+	case TimestampPolicyBuildTime:
+		return time.Now().UTC(), nil
+	default:
+		return time.Time{}, fmt.Errorf("unknown timestamp policy %q", p)
+	}
+}
@@ -0,0 +1,51 @@
+package build
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimestampPolicyResolve(t *testing.T) {
+	sde := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		policy TimestampPolicy
+		want   time.Time
+	}{
+		{"empty defaults to source date epoch", "", sde},
+		{"source date epoch", TimestampPolicySourceDateEpoch, sde},
+		{"zero", TimestampPolicyZero, time.Unix(0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.policy.Resolve(sde)
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Fatalf("Resolve(%q) = %v, want %v", tt.policy, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimestampPolicyResolveBuildTime(t *testing.T) {
+	before := time.Now().UTC()
+
+	got, err := TimestampPolicyBuildTime.Resolve(time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	if got.Before(before) {
+		t.Fatalf("Resolve(TimestampPolicyBuildTime) = %v, want a time at or after %v", got, before)
+	}
+}
+
+func TestTimestampPolicyResolveUnknown(t *testing.T) {
+	if _, err := TimestampPolicy("bogus").Resolve(time.Unix(0, 0)); err == nil {
+		t.Fatal("Resolve with an unknown policy returned nil error, want an error")
+	}
+}